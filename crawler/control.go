@@ -0,0 +1,149 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Controller exposes pause/resume/stop over a local Unix socket so a running
+// crawl can be steered without killing the process, e.g.:
+//
+//	echo pause  | nc -U crawl.sock
+//	echo resume | nc -U crawl.sock
+//	echo stop   | nc -U crawl.sock
+type Controller struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{}
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// NewController starts listening on socketPath for control commands. Any
+// existing socket file at that path is removed first so a crashed run's
+// stale socket doesn't block a new one.
+func NewController(socketPath string) (*Controller, error) {
+	if socketPath == "" {
+		return &Controller{stopCh: make(chan struct{}), resumed: make(chan struct{})}, nil
+	}
+
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error clearing control socket: %v", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening control socket: %v", err)
+	}
+
+	c := &Controller{
+		listener: listener,
+		resumed:  make(chan struct{}),
+		stopCh:   make(chan struct{}),
+	}
+
+	go c.acceptLoop()
+	return c, nil
+}
+
+func (c *Controller) acceptLoop() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+		go c.handleConn(conn)
+	}
+}
+
+func (c *Controller) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		cmd := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		switch cmd {
+		case "pause":
+			c.Pause()
+			fmt.Fprintln(conn, "ok: paused")
+		case "resume":
+			c.Resume()
+			fmt.Fprintln(conn, "ok: resumed")
+		case "stop":
+			c.Stop()
+			fmt.Fprintln(conn, "ok: stopping")
+		case "":
+			// ignore blank lines
+		default:
+			fmt.Fprintf(conn, "error: unknown command %q\n", cmd)
+		}
+	}
+}
+
+// Pause suspends workers at their next WaitIfPaused check.
+func (c *Controller) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		return
+	}
+	c.paused = true
+	c.resumed = make(chan struct{})
+}
+
+// Resume releases any workers blocked in WaitIfPaused.
+func (c *Controller) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		return
+	}
+	c.paused = false
+	close(c.resumed)
+}
+
+// WaitIfPaused blocks the calling worker while the crawl is paused.
+func (c *Controller) WaitIfPaused() {
+	for {
+		c.mu.Lock()
+		if !c.paused {
+			c.mu.Unlock()
+			return
+		}
+		resumed := c.resumed
+		c.mu.Unlock()
+		<-resumed
+	}
+}
+
+// Stop signals every worker to wind down.
+func (c *Controller) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+}
+
+// Stopped reports whether Stop has been called.
+func (c *Controller) Stopped() bool {
+	select {
+	case <-c.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close tears down the control socket listener, if any.
+func (c *Controller) Close() error {
+	if c.listener == nil {
+		return nil
+	}
+	return c.listener.Close()
+}
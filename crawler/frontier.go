@@ -0,0 +1,263 @@
+// Package crawler provides the on-disk crawl state used to drive broad,
+// multi-page crawls without holding the full visit queue in memory.
+package crawler
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	pendingFileName = "pending.log"
+	seenFileName    = "seen.idx"
+
+	// maxBufferedPending caps how many pending URLs are held in memory at
+	// once; the rest stay on disk in pending.log until Dequeue needs them.
+	// This is what keeps a huge multi-domain crawl from OOMing.
+	maxBufferedPending = 10000
+)
+
+// Frontier is a persistent, resumable queue of URLs to visit. Pending URLs
+// are appended to an on-disk log and read back lazily in maxBufferedPending-
+// sized chunks, and URL fingerprints of fully processed pages are appended
+// to a separate index, so a killed run can be resumed with --resume and
+// huge multi-domain crawls don't need to keep every pending URL's full
+// text in RAM. Only a compact fingerprint per ever-enqueued URL stays
+// resident (alongside the same for visited URLs), which is what lets
+// Enqueue dedup a popular link even once it has overflowed out of the
+// buffered queue.
+type Frontier struct {
+	mu sync.Mutex
+
+	pendingFile *os.File // append-only write handle
+	pendingRead *os.File // lazily-advancing read handle over the same log
+	pendingScan *bufio.Scanner
+	seenFile    *os.File
+
+	queue    []string
+	queued   map[string]struct{}
+	seen     map[string]struct{}
+	enqueued map[string]struct{} // fingerprints of every URL ever written to pending.log, buffered or not
+}
+
+// NewFrontier opens (or creates) the crawl state under stateDir. When resume
+// is false, any existing state in stateDir is discarded and the frontier
+// starts empty. When resume is true, previously pending URLs that have not
+// yet been marked visited are read back from pending.log as Dequeue needs
+// them, rather than all at once.
+func NewFrontier(stateDir string, resume bool) (*Frontier, error) {
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating state dir: %v", err)
+	}
+
+	pendingPath := filepath.Join(stateDir, pendingFileName)
+	seenPath := filepath.Join(stateDir, seenFileName)
+
+	if !resume {
+		if err := os.Remove(pendingPath); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error clearing pending queue: %v", err)
+		}
+		if err := os.Remove(seenPath); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error clearing seen index: %v", err)
+		}
+	}
+
+	f := &Frontier{
+		queued:   make(map[string]struct{}),
+		seen:     make(map[string]struct{}),
+		enqueued: make(map[string]struct{}),
+	}
+
+	if resume {
+		if err := f.loadSeen(seenPath); err != nil {
+			return nil, err
+		}
+		if err := f.loadEnqueued(pendingPath); err != nil {
+			return nil, err
+		}
+	}
+
+	seenFile, err := os.OpenFile(seenPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening seen index: %v", err)
+	}
+	f.seenFile = seenFile
+
+	pendingFile, err := os.OpenFile(pendingPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening pending queue: %v", err)
+	}
+	f.pendingFile = pendingFile
+
+	// pendingPath was just created (or already existed) above, so it's
+	// always safe to open for reading here too.
+	pendingRead, err := os.Open(pendingPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening pending queue for read: %v", err)
+	}
+	f.pendingRead = pendingRead
+	f.pendingScan = bufio.NewScanner(pendingRead)
+
+	return f, nil
+}
+
+// loadSeen reconstructs the set of already-processed URL fingerprints.
+func (f *Frontier) loadSeen(seenPath string) error {
+	file, err := os.Open(seenPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error reading seen index: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		f.seen[scanner.Text()] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// loadEnqueued reconstructs the set of fingerprints of every URL already
+// written to pending.log, so a resumed crawl doesn't re-write (and later
+// re-process) a duplicate line for a URL that's still pending from the
+// previous run but has overflowed the in-memory buffer.
+func (f *Frontier) loadEnqueued(pendingPath string) error {
+	file, err := os.Open(pendingPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error reading pending queue: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if url := scanner.Text(); url != "" {
+			f.enqueued[fingerprint(url)] = struct{}{}
+		}
+	}
+	return scanner.Err()
+}
+
+// refillLocked tops the in-memory buffer back up to maxBufferedPending by
+// reading the next lines from pending.log, skipping any URL that has
+// already been marked visited or is already buffered. f.mu must be held.
+func (f *Frontier) refillLocked() error {
+	for len(f.queue) < maxBufferedPending && f.pendingScan.Scan() {
+		url := f.pendingScan.Text()
+		if url == "" {
+			continue
+		}
+		if _, done := f.seen[fingerprint(url)]; done {
+			continue
+		}
+		if _, queued := f.queued[url]; queued {
+			continue
+		}
+		f.queued[url] = struct{}{}
+		f.queue = append(f.queue, url)
+	}
+	return f.pendingScan.Err()
+}
+
+// Enqueue adds url to the pending queue unless it has already been visited
+// or already written to pending.log. The URL is appended to the on-disk
+// log immediately so it survives a crash before it's dequeued; once the
+// in-memory buffer is full, the URL stays on disk until a later Dequeue
+// reads it back in, rather than being held in RAM. Deduping against
+// f.enqueued (fingerprints only, kept in full regardless of buffer state)
+// rather than the bounded f.queued keeps a popular URL from being written
+// to pending.log - and later re-processed - once per overflowing Enqueue.
+func (f *Frontier) Enqueue(url string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fp := fingerprint(url)
+	if _, done := f.seen[fp]; done {
+		return nil
+	}
+	if _, already := f.enqueued[fp]; already {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(f.pendingFile, url); err != nil {
+		return fmt.Errorf("error persisting pending URL: %v", err)
+	}
+	f.enqueued[fp] = struct{}{}
+
+	if len(f.queue) < maxBufferedPending {
+		f.queued[url] = struct{}{}
+		f.queue = append(f.queue, url)
+	}
+	return nil
+}
+
+// Dequeue pops the next pending URL, if any, refilling the in-memory
+// buffer from pending.log first if it has run dry.
+func (f *Frontier) Dequeue() (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.queue) == 0 {
+		if err := f.refillLocked(); err != nil {
+			return "", false
+		}
+	}
+	if len(f.queue) == 0 {
+		return "", false
+	}
+
+	url := f.queue[0]
+	f.queue = f.queue[1:]
+	delete(f.queued, url)
+	return url, true
+}
+
+// MarkVisited records url as fully processed so it is never re-enqueued,
+// including across a resumed run.
+func (f *Frontier) MarkVisited(url string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fp := fingerprint(url)
+	if _, done := f.seen[fp]; done {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(f.seenFile, fp); err != nil {
+		return fmt.Errorf("error persisting seen URL: %v", err)
+	}
+	f.seen[fp] = struct{}{}
+	return nil
+}
+
+// Len reports how many pending URLs are currently buffered in memory; it
+// does not include URLs still waiting to be read back from pending.log.
+func (f *Frontier) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.queue)
+}
+
+// Close flushes and closes the underlying state files.
+func (f *Frontier) Close() error {
+	if err := f.pendingFile.Close(); err != nil {
+		return err
+	}
+	if err := f.pendingRead.Close(); err != nil {
+		return err
+	}
+	return f.seenFile.Close()
+}
+
+// fingerprint returns a stable, compact hash for a URL.
+func fingerprint(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"sync"
+	"time"
+)
+
+// HostLimiter is a per-host token bucket used to throttle concurrent
+// fetches so a worker pool stays polite to any single host even while
+// hammering many hosts in parallel.
+type HostLimiter struct {
+	mu        sync.Mutex
+	rate      time.Duration // default minimum interval between requests to the same host
+	overrides map[string]time.Duration
+	buckets   map[string]time.Time
+}
+
+// NewHostLimiter returns a limiter that allows at most one request per
+// host every interval. An interval of zero disables throttling.
+func NewHostLimiter(interval time.Duration) *HostLimiter {
+	return &HostLimiter{
+		rate:      interval,
+		overrides: make(map[string]time.Duration),
+		buckets:   make(map[string]time.Time),
+	}
+}
+
+// SetHostInterval overrides the minimum interval for a specific host, e.g.
+// to honor that host's robots.txt Crawl-delay even when it's stricter
+// (or looser) than the crawl's default rate.
+func (l *HostLimiter) SetHostInterval(host string, interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overrides[host] = interval
+}
+
+// Wait blocks until host is allowed to be fetched again, then reserves the
+// next slot for it.
+func (l *HostLimiter) Wait(host string) {
+	rate := l.rateFor(host)
+	if rate <= 0 {
+		return
+	}
+
+	for {
+		l.mu.Lock()
+		next, ok := l.buckets[host]
+		now := time.Now()
+		if !ok || !now.Before(next) {
+			l.buckets[host] = now.Add(rate)
+			l.mu.Unlock()
+			return
+		}
+		wait := next.Sub(now)
+		l.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+func (l *HostLimiter) rateFor(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rate, ok := l.overrides[host]; ok {
+		return rate
+	}
+	return l.rate
+}
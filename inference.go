@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/chromedp"
+	"golang.org/x/net/html"
+)
+
+// inferFieldsFromPages loads up to sampleCount pages starting at config.URL
+// (following config.PaginationCSS the same way scrape does), clusters
+// repeated DOM subtrees on the first page that yields one, and proposes a
+// record selector plus child field selectors. It mirrors goskyr's
+// "infer" mode: normalize each node to a tag-path signature, group
+// same-signature siblings under their parent, score groups by
+// (count x path-depth x text-density), and take the highest-scoring
+// repeating group as the record container.
+func inferFieldsFromPages(config ScraperConfig, sampleCount int) (recordSelector string, fields map[string]string, err error) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", true),
+		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.110 Safari/537.36"),
+	)
+	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	defer cancel()
+
+	tabCtx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	currentURL := config.URL
+	for i := 0; i < sampleCount; i++ {
+		var outputHTML string
+		ctx, cancelNav := context.WithTimeout(tabCtx, time.Duration(config.Timeout)*time.Second)
+		runErr := chromedp.Run(ctx,
+			chromedp.Navigate(currentURL),
+			chromedp.Sleep(2*time.Second),
+			chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight)`, nil),
+			chromedp.Sleep(3*time.Second),
+			chromedp.OuterHTML("html", &outputHTML),
+		)
+		cancelNav()
+		if runErr != nil || outputHTML == "" {
+			break
+		}
+
+		doc, parseErr := goquery.NewDocumentFromReader(strings.NewReader(outputHTML))
+		if parseErr != nil {
+			break
+		}
+
+		recordSelector, fields = inferFromDocument(doc)
+		if recordSelector != "" {
+			return recordSelector, fields, nil
+		}
+
+		nextURL, exists := doc.Find(config.PaginationCSS).Attr("href")
+		if !exists {
+			break
+		}
+		if !strings.HasPrefix(nextURL, "http") {
+			nextURL = getBaseURL(currentURL) + nextURL
+		}
+		currentURL = nextURL
+	}
+
+	return "", nil, fmt.Errorf("no repeating record pattern found in %d sample page(s)", sampleCount)
+}
+
+// domGroup is a set of sibling elements sharing the same tag/class
+// signature under the same parent, e.g. every <li class="item"> inside a
+// given <ul>.
+type domGroup struct {
+	parent    *html.Node
+	signature string
+	depth     int
+	elements  []*goquery.Selection
+}
+
+// inferFromDocument picks the highest-scoring repeating group in doc and
+// proposes field selectors within its first element.
+func inferFromDocument(doc *goquery.Document) (string, map[string]string) {
+	groups := make(map[*html.Node]map[string]*domGroup)
+
+	doc.Find("*").Each(func(_ int, sel *goquery.Selection) {
+		node := sel.Get(0)
+		if node.Parent == nil || node.Type != html.ElementNode {
+			return
+		}
+		sig := nodeSignature(node)
+		byParent, ok := groups[node.Parent]
+		if !ok {
+			byParent = make(map[string]*domGroup)
+			groups[node.Parent] = byParent
+		}
+		group, ok := byParent[sig]
+		if !ok {
+			group = &domGroup{parent: node.Parent, signature: sig, depth: nodeDepth(node)}
+			byParent[sig] = group
+		}
+		group.elements = append(group.elements, sel)
+	})
+
+	var best *domGroup
+	var bestScore float64
+	for _, byParent := range groups {
+		for _, group := range byParent {
+			if len(group.elements) < 3 {
+				continue
+			}
+			score := float64(len(group.elements)) * float64(group.depth) * textDensity(group.elements)
+			if score > bestScore {
+				bestScore = score
+				best = group
+			}
+		}
+	}
+
+	if best == nil {
+		return "", nil
+	}
+
+	return best.signature, inferFieldSelectors(best.elements[0])
+}
+
+// nodeSignature normalizes a node to "tag.firstClass", which is stable
+// across repeated siblings while still discriminating between unrelated
+// element groups.
+func nodeSignature(node *html.Node) string {
+	sig := node.Data
+	if class := attrValue(node, "class"); class != "" {
+		if fields := strings.Fields(class); len(fields) > 0 {
+			sig += "." + fields[0]
+		}
+	}
+	return sig
+}
+
+func nodeDepth(node *html.Node) int {
+	depth := 0
+	for n := node; n != nil; n = n.Parent {
+		depth++
+	}
+	return depth
+}
+
+func attrValue(node *html.Node, name string) string {
+	for _, a := range node.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textDensity scores how much non-whitespace text an average element in
+// the group carries, on a 0-10 scale, favoring groups of content cards
+// over groups of e.g. empty wrapper divs.
+func textDensity(elements []*goquery.Selection) float64 {
+	if len(elements) == 0 {
+		return 0
+	}
+	var total int
+	for _, el := range elements {
+		total += len(strings.TrimSpace(el.Text()))
+	}
+	avg := float64(total) / float64(len(elements))
+	if avg > 10 {
+		avg = 10
+	}
+	return avg
+}
+
+// inferFieldSelectors walks exemplar's descendants and proposes one
+// Fields-map entry per leaf with non-empty text or an href/src attribute,
+// using the shortest selector (relative to exemplar) that uniquely
+// identifies it.
+func inferFieldSelectors(exemplar *goquery.Selection) map[string]string {
+	fields := make(map[string]string)
+	seenNames := make(map[string]int)
+
+	exemplar.Find("*").Each(func(_ int, sel *goquery.Selection) {
+		node := sel.Get(0)
+
+		if href, ok := sel.Attr("href"); ok && href != "" {
+			addField(fields, seenNames, "product_url", uniqueSelector(exemplar, sel)+"@attr:href")
+			return
+		}
+		if src, ok := sel.Attr("src"); ok && src != "" {
+			addField(fields, seenNames, "image_url", uniqueSelector(exemplar, sel)+"@attr:src")
+			return
+		}
+		if hasElementChildren(node) {
+			return
+		}
+		text := strings.TrimSpace(sel.Text())
+		if text == "" {
+			return
+		}
+		addField(fields, seenNames, guessFieldName(node, text), uniqueSelector(exemplar, sel))
+	})
+
+	return fields
+}
+
+func hasElementChildren(node *html.Node) bool {
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			return true
+		}
+	}
+	return false
+}
+
+// guessFieldName maps common title/price markup to the field names the
+// rest of the scraper already treats specially, falling back to a
+// generic name for anything else.
+func guessFieldName(node *html.Node, text string) string {
+	class := strings.ToLower(attrValue(node, "class"))
+	switch {
+	case node.Data == "h1" || node.Data == "h2" || node.Data == "h3" || strings.Contains(class, "title") || strings.Contains(class, "name"):
+		return "product_name"
+	case strings.Contains(class, "price") || strings.Contains(text, "$") || strings.Contains(text, "₩"):
+		return "price"
+	default:
+		return "text"
+	}
+}
+
+// addField records name -> selector, de-duplicating repeated guesses
+// (e.g. multiple "text" leaves) with a numeric suffix.
+func addField(fields map[string]string, seen map[string]int, name, selector string) {
+	if _, exists := fields[name]; !exists && seen[name] == 0 {
+		fields[name] = selector
+		seen[name] = 1
+		return
+	}
+	seen[name]++
+	fields[fmt.Sprintf("%s_%d", name, seen[name])] = selector
+}
+
+// uniqueSelector returns a CSS path for target relative to root: its own
+// "tag.class" when that matches exactly one descendant of root, otherwise
+// the full tag.class path from root down to target. The full path is not
+// checked for uniqueness and can still match several descendants.
+func uniqueSelector(root *goquery.Selection, target *goquery.Selection) string {
+	node := target.Get(0)
+	short := nodeSignature(node)
+	if root.Find(short).Length() == 1 {
+		return short
+	}
+
+	var segments []string
+	for n := node; n != nil && n != root.Get(0); n = n.Parent {
+		if n.Type == html.ElementNode {
+			segments = append([]string{nodeSignature(n)}, segments...)
+		}
+	}
+	return strings.Join(segments, " ")
+}
+
+// writeFieldsFile saves fields as a ready-to-use fields.json matching the
+// existing Fields map format.
+func writeFieldsFile(path string, fields map[string]string) error {
+	data, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
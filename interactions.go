@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"gopkg.in/yaml.v3"
+)
+
+// Interaction describes a single pre-extraction step to run against the
+// loaded page before it is scraped, e.g. dismissing a cookie banner,
+// clicking "Load more", or scrolling an infinite-scroll container into
+// view. A list of these replaces the hardcoded
+// `scrollTo(0, scrollHeight)` for sites that need more than one step.
+type Interaction struct {
+	// Type is one of: click, type, wait, scroll, select, hover, eval.
+	Type string `json:"type" yaml:"type"`
+	// Selector is the CSS selector the interaction acts on. Not used by eval.
+	Selector string `json:"selector,omitempty" yaml:"selector,omitempty"`
+	// Value carries the text to type, the option value to select, or the
+	// JavaScript expression to evaluate, depending on Type.
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+	// Count repeats the interaction this many times (default 1), useful for
+	// clicking "Load more" or scrolling several times in a row.
+	Count int `json:"count,omitempty" yaml:"count,omitempty"`
+	// DelayMs pauses after each repetition of the interaction.
+	DelayMs int `json:"delay_ms,omitempty" yaml:"delay_ms,omitempty"`
+	// WaitNetworkIdle waits for a lull in network activity after the
+	// interaction runs, useful when it triggers an XHR/fetch for more
+	// content (infinite scroll, "Load more" buttons).
+	WaitNetworkIdle bool `json:"wait_network_idle,omitempty" yaml:"wait_network_idle,omitempty"`
+}
+
+// loadInteractions reads an ordered list of Interactions from a JSON or
+// YAML file, selected by file extension.
+func loadInteractions(path string) ([]Interaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading interactions file: %v", err)
+	}
+
+	var interactions []Interaction
+	if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
+		err = yaml.Unmarshal(data, &interactions)
+	} else {
+		err = json.Unmarshal(data, &interactions)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing interactions file: %v", err)
+	}
+	return interactions, nil
+}
+
+// interactionActions converts interactions into a sequence of chromedp
+// actions to run against the already-navigated page, in order.
+func interactionActions(interactions []Interaction) []chromedp.Action {
+	var actions []chromedp.Action
+	for _, in := range interactions {
+		count := in.Count
+		if count < 1 {
+			count = 1
+		}
+
+		for i := 0; i < count; i++ {
+			actions = append(actions, interactionAction(in))
+			if in.DelayMs > 0 {
+				actions = append(actions, chromedp.Sleep(time.Duration(in.DelayMs)*time.Millisecond))
+			}
+			if in.WaitNetworkIdle {
+				actions = append(actions, waitNetworkIdle(500*time.Millisecond, 10*time.Second))
+			}
+		}
+	}
+	return actions
+}
+
+// interactionAction returns the chromedp action for a single interaction.
+// Unknown types are treated as a no-op so a typo in an interactions file doesn't
+// abort the whole page.
+func interactionAction(in Interaction) chromedp.Action {
+	switch strings.ToLower(in.Type) {
+	case "click":
+		return chromedp.Click(in.Selector, chromedp.ByQuery)
+	case "type":
+		return chromedp.SendKeys(in.Selector, in.Value, chromedp.ByQuery)
+	case "wait":
+		return chromedp.WaitVisible(in.Selector, chromedp.ByQuery)
+	case "scroll":
+		return chromedp.ScrollIntoView(in.Selector, chromedp.ByQuery)
+	case "select":
+		return chromedp.SetValue(in.Selector, in.Value, chromedp.ByQuery)
+	case "hover":
+		return chromedp.ActionFunc(func(ctx context.Context) error {
+			return chromedp.Run(ctx, chromedp.ScrollIntoView(in.Selector, chromedp.ByQuery),
+				chromedp.Evaluate(fmt.Sprintf(
+					`document.querySelector(%q)?.dispatchEvent(new MouseEvent('mouseover', {bubbles: true}))`,
+					in.Selector), nil))
+		})
+	case "eval":
+		return chromedp.Evaluate(in.Value, nil)
+	default:
+		return chromedp.ActionFunc(func(context.Context) error { return nil })
+	}
+}
+
+// waitNetworkIdle waits until idleFor elapses without any request being
+// sent, finishing or failing, giving up after maxWait. Requests still in
+// flight are not counted, so a single slow request does not hold it
+// open. This lets an interaction that
+// triggers an XHR (e.g. "Load more") settle before the next step runs.
+func waitNetworkIdle(idleFor, maxWait time.Duration) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		var mu sync.Mutex
+		timer := time.NewTimer(idleFor)
+		defer timer.Stop()
+
+		chromedp.ListenTarget(ctx, func(ev interface{}) {
+			switch ev.(type) {
+			case *network.EventRequestWillBeSent, *network.EventLoadingFinished, *network.EventLoadingFailed:
+				mu.Lock()
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(idleFor)
+				mu.Unlock()
+			}
+		})
+
+		select {
+		case <-timer.C:
+			return nil
+		case <-time.After(maxWait):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
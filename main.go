@@ -2,17 +2,24 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	neturl "net/url"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
+
+	"github.com/crawler/crawler"
+	"github.com/crawler/politeness"
+	"github.com/crawler/rules"
 )
 
 // DataRecord represents a single record of scraped data
@@ -29,21 +36,70 @@ type ScraperConfig struct {
 	PaginationCSS string
 	RecordCSS     string
 	Fields        map[string]string
+	Workers       int           // Number of concurrent chromedp tabs fetching pages
+	Interactions  []Interaction // Pre-extraction steps (click, type, scroll, ...) to run on each page
+	RespectRobots bool          // Gate navigation through robots.txt Disallow/Crawl-delay
+}
+
+// ruleListFlag collects repeated -scraper-rule flags, each a JSON-encoded
+// rules.Rule, e.g. -scraper-rule '{"name":"ids","type":"regex","pattern":"ID-\\d+"}'.
+type ruleListFlag struct {
+	rules *[]rules.Rule
+}
+
+func (f *ruleListFlag) String() string { return "" }
+
+func (f *ruleListFlag) Set(value string) error {
+	var rule rules.Rule
+	if err := json.Unmarshal([]byte(value), &rule); err != nil {
+		return fmt.Errorf("error parsing -scraper-rule: %v", err)
+	}
+	*f.rules = append(*f.rules, rule)
+	return nil
 }
 
 func main() {
 	// Parse command line flags
 	url := flag.String("url", "https://www.coupang.com/np/categories/195756", "URL to scrape")
 	fieldsMapping := flag.String("mapping", "input/coupang_fields.json", "Mapping of the fields to be extracted")
-	outputFormat := flag.String("format", "json", "Output format (json or csv)")
+	outputFormat := flag.String("format", "json", "Output format (json, csv, jsonl, sqlite or warc)")
 	outputFile := flag.String("output", "coupang", "Output file name (without extension)")
 	maxRecords := flag.Int("max", 100, "Maximum number of records to scrape")
 	waitTime := flag.Int("wait", 2, "Timeout configuration")
 	paginationCSS := flag.String("pagination", "a.next-page", "CSS selector for pagination element")
 	recordCSS := flag.String("record", "li.baby-product", "CSS selector for record elements")
 	scrapeTimeout := flag.Int("timeout", 45, "Timeout in seconds for chromedp operations") // New flag
+	stateDir := flag.String("state-dir", "state", "Directory for persisted crawl state (pending queue + seen index)")
+	resume := flag.Bool("resume", false, "Resume a previous crawl from --state-dir instead of starting fresh")
+	workers := flag.Int("workers", 1, "Number of concurrent chromedp tabs fetching pages")
+	controlSocket := flag.String("control-socket", "", "Unix socket path for runtime pause/resume/stop control (disabled if empty)")
+	interactionsFile := flag.String("interactions", "", "JSON/YAML file of pre-extraction interactions (click, type, scroll, ...) to run on each page")
+	respectRobots := flag.Bool("respect-robots", true, "Honor robots.txt Disallow and Crawl-delay directives")
+	userAgentToken := flag.String("user-agent-token", "crawler", "Token matched against robots.txt User-agent groups")
+	sitemapSeed := flag.String("sitemap-seed", "", "Sitemap (or sitemap index) URL to seed the frontier from")
+	infer := flag.Bool("infer", false, "Infer a record selector and fields.json from sample pages instead of scraping")
+	inferSamples := flag.Int("infer-samples", 3, "Number of sample pages to load for --infer")
+	inferOutput := flag.String("infer-output", "fields.json", "Where to write the inferred fields.json")
+	enableBuiltinRules := flag.Bool("scraper-rules", false, "Run the built-in post-extraction scraper rules (emails, links, JS sources, phone numbers, crypto addresses)")
+	rulesDir := flag.String("rules-dir", "", "Directory of *.json scraper rule files to load in addition to --scraper-rules/--scraper-rule")
+	var customRules []rules.Rule
+	flag.Var(&ruleListFlag{rules: &customRules}, "scraper-rule", `Custom scraper rule as JSON, e.g. {"name":"ids","type":"regex","pattern":"ID-\\d+"} (repeatable)`)
 	flag.Parse()
 
+	if *infer {
+		config := ScraperConfig{URL: *url, PaginationCSS: *paginationCSS, Timeout: *scrapeTimeout}
+		recordSelector, fields, err := inferFieldsFromPages(config, *inferSamples)
+		if err != nil {
+			log.Fatalf("Error inferring fields: %v", err)
+		}
+		if err := writeFieldsFile(*inferOutput, fields); err != nil {
+			log.Fatalf("Error writing %s: %v", *inferOutput, err)
+		}
+		fmt.Printf("Inferred record selector %q and %d fields, wrote %s\n", recordSelector, len(fields), *inferOutput)
+		fmt.Printf("Re-run with --record=%q --mapping=%s to scrape using it.\n", recordSelector, *inferOutput)
+		return
+	}
+
 	mappings, err := os.ReadFile(*fieldsMapping)
 	if err != nil {
 		log.Fatalf("Error reading fields mapping file: %v", err)
@@ -57,6 +113,14 @@ func main() {
 		return
 	}
 
+	var interactions []Interaction
+	if *interactionsFile != "" {
+		interactions, err = loadInteractions(*interactionsFile)
+		if err != nil {
+			log.Fatalf("Error loading interactions file: %v", err)
+		}
+	}
+
 	// Create scraper config
 	config := ScraperConfig{
 		URL:           *url,
@@ -68,13 +132,74 @@ func main() {
 		PaginationCSS: *paginationCSS,
 		RecordCSS:     *recordCSS,
 		Fields:        fields,
+		Workers:       *workers,
+		Interactions:  interactions,
+		RespectRobots: *respectRobots,
+	}
+
+	frontier, err := crawler.NewFrontier(*stateDir, *resume)
+	if err != nil {
+		log.Fatalf("Error opening crawl state: %v", err)
+	}
+	defer frontier.Close()
+
+	if *sitemapSeed != "" {
+		urls, err := politeness.FetchSitemapURLs(*sitemapSeed)
+		if err != nil {
+			log.Fatalf("Error seeding from sitemap: %v", err)
+		}
+		for _, seedURL := range urls {
+			if err := frontier.Enqueue(seedURL); err != nil {
+				log.Fatalf("Error queuing sitemap URL: %v", err)
+			}
+		}
+		fmt.Printf("Seeded %d URLs from sitemap %s\n", len(urls), *sitemapSeed)
+	}
+
+	controller, err := crawler.NewController(*controlSocket)
+	if err != nil {
+		log.Fatalf("Error starting control socket: %v", err)
+	}
+	defer controller.Close()
+
+	var ruleSet []rules.Rule
+	if *enableBuiltinRules {
+		ruleSet = append(ruleSet, rules.BuiltinRules()...)
+	}
+	if *rulesDir != "" {
+		dirRules, err := rules.LoadDir(*rulesDir)
+		if err != nil {
+			log.Fatalf("Error loading scraper rules: %v", err)
+		}
+		ruleSet = append(ruleSet, dirRules...)
+	}
+	ruleSet = append(ruleSet, customRules...)
+
+	var ruleEngine *rules.Engine
+	if len(ruleSet) > 0 {
+		ruleEngine, err = rules.NewEngine(ruleSet)
+		if err != nil {
+			log.Fatalf("Error building scraper rules: %v", err)
+		}
+		defer ruleEngine.Close()
+	}
+
+	sink, err := newOutputSink(config)
+	if err != nil {
+		log.Fatalf("Error opening output sink: %v", err)
+	}
+	defer sink.Close()
+
+	var policy *politeness.Policy
+	if config.RespectRobots {
+		policy = politeness.NewPolicy(*userAgentToken)
 	}
 
 	var records []DataRecord
 	// Run the scraper
 	for i := 0; i < 3; i++ {
 		var err error
-		records, err = scrape(config)
+		records, err = scrape(config, frontier, controller, sink, policy, ruleEngine)
 		if err != nil || len(records) == 0 {
 			time.Sleep(2 * time.Second)
 			log.Printf("======Error scraping data: %v. Retrying...======", err)
@@ -84,18 +209,32 @@ func main() {
 	}
 
 	// Save the data
-	if err := saveData(records, config); err != nil {
-		log.Fatalf("Error saving data: %v", err)
+	for _, record := range records {
+		if err := sink.WriteRecord(record); err != nil {
+			log.Fatalf("Error saving data: %v", err)
+		}
 	}
 
 	// Print summary
 	printSummary(records)
 }
 
-// scrape uses chromedp for JavaScript rendering
-func scrape(config ScraperConfig) ([]DataRecord, error) {
+// scrape uses chromedp for JavaScript rendering. Pages to visit (the seed
+// URL plus any pagination links discovered along the way) are driven through
+// frontier so a killed run can be resumed with --resume instead of starting
+// the crawl over from scratch. config.Workers pages are fetched concurrently,
+// each in its own chromedp tab sharing the browser allocator below, while
+// controller lets an operator pause/resume/stop the crawl at runtime, and
+// policy (if non-nil) gates every navigation through the host's robots.txt,
+// and ruleEngine (if non-nil) runs the post-extraction scraper rules over
+// every fetched page.
+func scrape(config ScraperConfig, frontier *crawler.Frontier, controller *crawler.Controller, sink OutputSink, policy *politeness.Policy, ruleEngine *rules.Engine) ([]DataRecord, error) {
 	var records []DataRecord
 
+	if err := frontier.Enqueue(config.URL); err != nil {
+		return nil, fmt.Errorf("error seeding frontier: %v", err)
+	}
+
 	// Create a new context with options
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("headless", true),
@@ -110,232 +249,309 @@ func scrape(config ScraperConfig) ([]DataRecord, error) {
 	parentCtx, cancel := chromedp.NewContext(allocCtx)
 	defer cancel()
 
-	baseConfigURL := config.URL
-	currentURL := config.URL
-	hasNextPage := true
-	pageNum := 0
 	if err := chromedp.Run(parentCtx); err != nil {
 		log.Fatal("context timeout reached, attempting to perform actions", err)
 	}
 
-	for hasNextPage && len(records) < config.MaxRecords {
-		fmt.Printf("Scraping page %d with headless browser: %s\n", pageNum, currentURL)
-
-		// Navigate to the page and wait for content to load
-		var outputHtml string
-
-		ctx1, cancel2 := context.WithTimeout(parentCtx, time.Duration(config.Timeout)*time.Second)
-		defer cancel2() // Ensure the context is canceled to release resources
-
-		if err1 := chromedp.Run(ctx1,
-			chromedp.Navigate(currentURL),
-			chromedp.Sleep(2*time.Second),
-			// Wait for product elements to be visible
-			chromedp.WaitVisible(config.RecordCSS, chromedp.ByQuery),
-			// Scroll down to load lazy-loaded content
-			chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight)`, nil),
-			chromedp.Sleep(8*time.Second),
-			chromedp.OuterHTML("html", &outputHtml),
-		); err1 != nil {
-			log.Println("context timeout reached, attempting to perform actions", err1)
+	limiter := crawler.NewHostLimiter(time.Duration(config.WaitTime) * time.Second)
+	var pageNum int32
 
-			ctx2, cancel := context.WithTimeout(parentCtx, time.Duration(15)*time.Second)
-			defer cancel() // Ensure the context is canceled to release resources
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
 
-			_ = chromedp.Run(ctx2,
-				chromedp.OuterHTML("html", &outputHtml),
-			)
-		}
-		if outputHtml == "" {
-			return records, nil
-		}
+	var (
+		mu    sync.Mutex
+		idle  int32
+		wg    sync.WaitGroup
+		fatal error
+	)
 
-		// Parse the HTML
-		doc, err := goquery.NewDocumentFromReader(strings.NewReader(outputHtml))
-		if err != nil {
-			return nil, fmt.Errorf("error parsing HTML: %v", err)
-		}
+	recordsFull := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(records) >= config.MaxRecords
+	}
 
-		// Extract records from the current page
-		productCount := 0
-		doc.Find(config.RecordCSS).Each(func(i int, s *goquery.Selection) {
-			if len(records) >= config.MaxRecords {
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+
+			// Each worker gets its own tab in the shared browser allocator,
+			// so pages can be fetched concurrently without stepping on
+			// another worker's navigation.
+			tabCtx, cancelTab := chromedp.NewContext(parentCtx)
+			defer cancelTab()
+			if err := chromedp.Run(tabCtx, network.Enable()); err != nil {
+				mu.Lock()
+				if fatal == nil {
+					fatal = fmt.Errorf("error enabling network domain: %v", err)
+				}
+				mu.Unlock()
 				return
 			}
 
-			record := make(DataRecord)
-			for field, selector := range config.Fields {
-				var value string
-				if strings.Contains(selector, "@attr:") {
-					// Extract attribute
-					parts := strings.Split(selector, "@attr:")
-					baseSelector := parts[0]
-					attrName := parts[1]
-					value, _ = s.Find(baseSelector).Attr(attrName)
-
-					// Handle relative URLs for images and links
-					if (field == "image_url" || field == "product_url") && value != "" && !strings.HasPrefix(value, "http") {
-						baseURL := getBaseURL(currentURL)
-						value = baseURL + value
+			for {
+				if controller != nil {
+					if controller.Stopped() {
+						return
 					}
-				} else {
-					// Extract text
-					value = strings.TrimSpace(s.Find(selector).Text())
+					controller.WaitIfPaused()
 				}
-				record[field] = value
-			}
 
-			// Only add record if it has a product name
-			if record["product_name"] != "" {
-				records = append(records, record)
-				productCount++
-			}
-		})
+				if recordsFull() {
+					return
+				}
+
+				currentURL, ok := frontier.Dequeue()
+				if !ok {
+					n := atomic.AddInt32(&idle, 1)
+					if int(n) >= workers {
+						// every worker found the frontier empty at once:
+						// there is no more work coming.
+						return
+					}
+					time.Sleep(200 * time.Millisecond)
+					atomic.AddInt32(&idle, -1)
+					continue
+				}
+				atomic.StoreInt32(&idle, 0)
+
+				if policy != nil {
+					allowed, err := policy.Allowed(currentURL)
+					if err != nil {
+						mu.Lock()
+						if fatal == nil {
+							fatal = fmt.Errorf("error checking robots.txt: %v", err)
+						}
+						mu.Unlock()
+						continue
+					}
+					if !allowed {
+						fmt.Printf("Skipping %s: disallowed by robots.txt\n", currentURL)
+						_ = frontier.MarkVisited(currentURL)
+						continue
+					}
+					if delay, err := policy.CrawlDelay(currentURL); err == nil && delay > 0 {
+						limiter.SetHostInterval(hostOf(currentURL), delay)
+					}
+				}
 
-		fmt.Printf("Found %d products on page %d\n", productCount, pageNum)
+				limiter.Wait(hostOf(currentURL))
 
-		// Check for pagination
-		hasNextPage = false
-		pagination := doc.Find(config.PaginationCSS)
+				pageRecords, nextURL, err := fetchPage(tabCtx, config, currentURL, &pageNum, sink, ruleEngine)
+				if err != nil {
+					mu.Lock()
+					if fatal == nil {
+						fatal = err
+					}
+					mu.Unlock()
+					continue
+				}
 
-		nextURL, exists := pagination.Attr("href")
-		if exists && productCount > 0 && strings.Contains(nextURL, "page=") {
-			// Handle relative URLs
-			if !strings.HasPrefix(nextURL, "http") {
-				baseURL := getBaseURL(currentURL)
-				nextURL = baseURL + nextURL
-			}
-			currentURL = nextURL
-			hasNextPage = true
-			pageNum++
-		} else if productCount > 0 {
-			pageNum++
-			if strings.Contains(baseConfigURL, "?") {
-				currentURL = baseConfigURL + "&page="
-			} else {
-				currentURL = baseConfigURL + "?page="
+				if err := frontier.MarkVisited(currentURL); err != nil {
+					mu.Lock()
+					if fatal == nil {
+						fatal = fmt.Errorf("error persisting crawl progress: %v", err)
+					}
+					mu.Unlock()
+					continue
+				}
+
+				if nextURL != "" {
+					if err := frontier.Enqueue(nextURL); err != nil {
+						mu.Lock()
+						if fatal == nil {
+							fatal = fmt.Errorf("error queuing next page: %v", err)
+						}
+						mu.Unlock()
+					}
+				}
+
+				if len(pageRecords) > 0 {
+					mu.Lock()
+					records = append(records, pageRecords...)
+					mu.Unlock()
+				}
 			}
-			currentURL += fmt.Sprint(pageNum)
-			hasNextPage = true
-		}
+		}()
+	}
+	wg.Wait()
 
-		// Rate limiting
-		time.Sleep(time.Duration(config.WaitTime) * time.Second)
+	if len(records) > config.MaxRecords {
+		records = records[:config.MaxRecords]
 	}
 
-	return records, nil
+	return records, fatal
 }
 
-// getBaseURL extracts the base URL from a full URL
-func getBaseURL(url string) string {
-	parts := strings.Split(url, "/")
-	if len(parts) < 3 {
-		return url
+// fetchPage navigates to pageURL in tabCtx, extracts records matching
+// config.RecordCSS/Fields, and works out the next page to enqueue (if any).
+// pageNum is shared across workers purely for logging and for the
+// fallback "?page=N" pagination scheme. If sink implements PageArchiver,
+// the raw page HTML is handed to it (e.g. for WARC archiving). If
+// ruleEngine is non-nil, its scraper rules always run over the fetched
+// page regardless of whether any DataRecord was extracted from it (so
+// --scraper-rules still harvests from non-catalog pages), and their
+// matches are attached to every extracted record under "matches".
+func fetchPage(tabCtx context.Context, config ScraperConfig, pageURL string, pageNum *int32, sink OutputSink, ruleEngine *rules.Engine) ([]DataRecord, string, error) {
+	fmt.Printf("Scraping page %d with headless browser: %s\n", atomic.LoadInt32(pageNum), pageURL)
+
+	// Navigate to the page and wait for content to load
+	var outputHtml string
+
+	ctx1, cancel2 := context.WithTimeout(tabCtx, time.Duration(config.Timeout)*time.Second)
+	defer cancel2() // Ensure the context is canceled to release resources
+
+	actions := []chromedp.Action{
+		chromedp.Navigate(pageURL),
+		chromedp.Sleep(2 * time.Second),
+		// Wait for product elements to be visible
+		chromedp.WaitVisible(config.RecordCSS, chromedp.ByQuery),
 	}
-
-	// Handle URLs with http/https
-	if parts[0] == "http:" || parts[0] == "https:" {
-		baseURL := parts[0] + "//" + parts[2] + "/"
-		return baseURL
+	if len(config.Interactions) > 0 {
+		// Run the configured click/type/scroll/... steps instead of the
+		// one-size-fits-all scrollTo below, for sites that hide content
+		// behind "Load more" buttons, cookie banners, tabs, etc.
+		actions = append(actions, interactionActions(config.Interactions)...)
+	} else {
+		// Scroll down to load lazy-loaded content
+		actions = append(actions,
+			chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight)`, nil),
+			chromedp.Sleep(8*time.Second),
+		)
 	}
+	actions = append(actions, chromedp.OuterHTML("html", &outputHtml))
 
-	return url
-}
+	if err1 := chromedp.Run(ctx1, actions...); err1 != nil {
+		log.Println("context timeout reached, attempting to perform actions", err1)
 
-// saveData saves the scraped data to a file in the specified format
-func saveData(records []DataRecord, config ScraperConfig) error {
-	fileName := config.OutputFile
+		ctx2, cancel := context.WithTimeout(tabCtx, time.Duration(15)*time.Second)
+		defer cancel() // Ensure the context is canceled to release resources
 
-	switch strings.ToLower(config.OutputFormat) {
-	case "json":
-		fileName += ".json"
-		return saveAsJSON(records, fileName)
-	case "csv":
-		fileName += ".csv"
-		return saveAsCSV(records, fileName)
-	default:
-		return fmt.Errorf("unsupported output format: %s", config.OutputFormat)
+		_ = chromedp.Run(ctx2,
+			chromedp.OuterHTML("html", &outputHtml),
+		)
+	}
+	if outputHtml == "" {
+		return nil, "", nil
 	}
-}
-
-// saveAsJSON saves the data as JSON
-func saveAsJSON(records []DataRecord, fileName string) error {
-	// Fix URLs before saving
-	for i := range records {
-		// Fix product_url
-		if url, ok := records[i]["product_url"]; ok && url != "" {
-			// Fix multiple slashes
-			url = strings.Replace(url, "//vp", "/vp", -1)
-			// Fix escaped characters - using a more direct approach
-			url = strings.ReplaceAll(url, "\\u0026", "&")
-			url = strings.ReplaceAll(url, "\u0026", "&")
-			records[i]["product_url"] = url
-		}
 
-		// Fix image_url
-		if url, ok := records[i]["image_url"]; ok && url != "" {
-			// Fix multiple slashes
-			url = strings.Replace(url, "///thumbnail", "//thumbnail", -1)
-			url = strings.Replace(url, "//thumbnail", "/thumbnail", -1)
-			// Make sure we have proper protocol slashes
-			if strings.HasPrefix(url, "https:/") && !strings.HasPrefix(url, "https://") {
-				url = strings.Replace(url, "https:/", "https://", 1)
-			}
-			records[i]["image_url"] = url
+	if archiver, ok := sink.(PageArchiver); ok {
+		if err := archiver.WritePage(pageURL, []byte(outputHtml), time.Now()); err != nil {
+			return nil, "", fmt.Errorf("error archiving page: %v", err)
 		}
 	}
 
-	file, err := os.Create(fileName)
+	// Parse the HTML
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(outputHtml))
 	if err != nil {
-		return err
+		return nil, "", fmt.Errorf("error parsing HTML: %v", err)
 	}
-	defer file.Close()
 
-	// Use a custom encoder that doesn't escape HTML characters
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	encoder.SetEscapeHTML(false)
-	return encoder.Encode(records)
-}
+	// Extract records from the current page
+	var records []DataRecord
+	doc.Find(config.RecordCSS).Each(func(i int, s *goquery.Selection) {
+		record := make(DataRecord)
+		for field, selector := range config.Fields {
+			var value string
+			if strings.Contains(selector, "@attr:") {
+				// Extract attribute
+				parts := strings.Split(selector, "@attr:")
+				baseSelector := parts[0]
+				attrName := parts[1]
+				value, _ = s.Find(baseSelector).Attr(attrName)
+
+				// Handle relative URLs for images and links
+				if (field == "image_url" || field == "product_url") && value != "" && !strings.HasPrefix(value, "http") {
+					baseURL := getBaseURL(pageURL)
+					value = baseURL + value
+				}
+			} else {
+				// Extract text
+				value = strings.TrimSpace(s.Find(selector).Text())
+			}
+			record[field] = value
+		}
 
-// saveAsCSV saves the data as CSV
-func saveAsCSV(records []DataRecord, fileName string) error {
-	file, err := os.Create(fileName)
-	if err != nil {
-		return err
+		// Only add record if it has a product name
+		if record["product_name"] != "" {
+			records = append(records, record)
+		}
+	})
+
+	if ruleEngine != nil {
+		matches, err := ruleEngine.Run(pageURL, outputHtml, doc)
+		if err != nil {
+			return nil, "", fmt.Errorf("error running scraper rules: %v", err)
+		}
+		if len(matches) > 0 && len(records) > 0 {
+			matchesJSON, err := json.Marshal(matches)
+			if err != nil {
+				return nil, "", fmt.Errorf("error encoding scraper rule matches: %v", err)
+			}
+			for _, record := range records {
+				record["matches"] = string(matchesJSON)
+			}
+		}
 	}
-	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	productCount := len(records)
+	fmt.Printf("Found %d products on page %d\n", productCount, atomic.LoadInt32(pageNum))
 
-	// Extract headers from the first record
-	if len(records) == 0 {
-		return nil
+	// Check for pagination
+	var nextURL string
+	pagination := doc.Find(config.PaginationCSS)
+
+	href, exists := pagination.Attr("href")
+	if exists && productCount > 0 && strings.Contains(href, "page=") {
+		// Handle relative URLs
+		if !strings.HasPrefix(href, "http") {
+			baseURL := getBaseURL(pageURL)
+			href = baseURL + href
+		}
+		nextURL = href
+		atomic.AddInt32(pageNum, 1)
+	} else if productCount > 0 {
+		n := atomic.AddInt32(pageNum, 1)
+		baseConfigURL := config.URL
+		if strings.Contains(baseConfigURL, "?") {
+			nextURL = baseConfigURL + "&page="
+		} else {
+			nextURL = baseConfigURL + "?page="
+		}
+		nextURL += fmt.Sprint(n)
 	}
 
-	var headers []string
-	for key := range records[0] {
-		headers = append(headers, key)
+	return records, nextURL, nil
+}
+
+// hostOf returns the host component of a URL, or the URL itself if it
+// cannot be parsed (better to rate-limit too broadly than not at all).
+func hostOf(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
 	}
+	return u.Host
+}
 
-	// Write headers
-	if err := writer.Write(headers); err != nil {
-		return err
+// getBaseURL extracts the base URL from a full URL
+func getBaseURL(url string) string {
+	parts := strings.Split(url, "/")
+	if len(parts) < 3 {
+		return url
 	}
 
-	// Write data
-	for _, record := range records {
-		row := make([]string, len(headers))
-		for i, header := range headers {
-			row[i] = record[header]
-		}
-		if err := writer.Write(row); err != nil {
-			return err
-		}
+	// Handle URLs with http/https
+	if parts[0] == "http:" || parts[0] == "https:" {
+		baseURL := parts[0] + "//" + parts[2] + "/"
+		return baseURL
 	}
 
-	return nil
+	return url
 }
 
 // printSummary prints a summary of the scraped data
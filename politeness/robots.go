@@ -0,0 +1,202 @@
+// Package politeness gates crawling behind each host's robots.txt (and
+// the sitemaps it advertises) so a crawl stays within the rules a site
+// operator has published instead of blindly hitting every URL.
+package politeness
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy fetches and caches robots.txt per host and answers whether a
+// given URL may be fetched under it.
+type Policy struct {
+	client    *http.Client
+	userAgent string // token matched against "User-agent:" groups
+
+	mu    sync.Mutex
+	cache map[string]*hostRules
+}
+
+// hostRules is the parsed robots.txt for a single host.
+type hostRules struct {
+	disallow   []string
+	crawlDelay time.Duration
+	sitemaps   []string
+	fetchErr   error
+}
+
+// NewPolicy returns a Policy that matches robots.txt "User-agent:" groups
+// against userAgentToken (falling back to "*" when no specific group
+// applies).
+func NewPolicy(userAgentToken string) *Policy {
+	return &Policy{
+		client:    &http.Client{Timeout: 10 * time.Second},
+		userAgent: userAgentToken,
+		cache:     make(map[string]*hostRules),
+	}
+}
+
+// Allowed reports whether rawURL may be fetched under its host's
+// robots.txt. A robots.txt that can't be fetched is treated as allow-all,
+// matching standard crawler behavior.
+func (p *Policy) Allowed(rawURL string) (bool, error) {
+	rules, err := p.rulesFor(rawURL)
+	if err != nil {
+		return true, err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return true, fmt.Errorf("error parsing URL: %v", err)
+	}
+
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	for _, disallowed := range rules.disallow {
+		if disallowed != "" && strings.HasPrefix(path, disallowed) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// CrawlDelay returns the Crawl-delay directive for rawURL's host, or zero
+// if none was published.
+func (p *Policy) CrawlDelay(rawURL string) (time.Duration, error) {
+	rules, err := p.rulesFor(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	return rules.crawlDelay, nil
+}
+
+// Sitemaps returns the Sitemap directives for rawURL's host.
+func (p *Policy) Sitemaps(rawURL string) ([]string, error) {
+	rules, err := p.rulesFor(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return rules.sitemaps, nil
+}
+
+// rulesFor fetches (or returns the cached) robots.txt for rawURL's host.
+func (p *Policy) rulesFor(rawURL string) (*hostRules, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing URL: %v", err)
+	}
+
+	p.mu.Lock()
+	if rules, ok := p.cache[u.Host]; ok {
+		p.mu.Unlock()
+		return rules, rules.fetchErr
+	}
+	p.mu.Unlock()
+
+	rules := p.fetch(u)
+
+	p.mu.Lock()
+	p.cache[u.Host] = rules
+	p.mu.Unlock()
+
+	return rules, rules.fetchErr
+}
+
+func (p *Policy) fetch(u *url.URL) *hostRules {
+	robotsURL := fmt.Sprintf("%s://%s/robots.txt", u.Scheme, u.Host)
+
+	resp, err := p.client.Get(robotsURL)
+	if err != nil {
+		return &hostRules{}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &hostRules{}
+	}
+
+	rules, err := parseRobots(resp.Body, p.userAgent)
+	if err != nil {
+		return &hostRules{fetchErr: err}
+	}
+	return rules
+}
+
+// parseRobots implements the subset of the robots.txt spec this crawler
+// needs: User-agent groups, Disallow, Crawl-delay, and Sitemap. A group
+// matching userAgent wins outright; otherwise the "*" group is used.
+func parseRobots(r io.Reader, userAgent string) (*hostRules, error) {
+	specific := &hostRules{}
+	wildcard := &hostRules{}
+	matchedSpecific := false
+	var sitemaps []string
+
+	var current *hostRules
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := splitDirective(line)
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "user-agent":
+			agent := strings.ToLower(value)
+			if agent == "*" {
+				current = wildcard
+			} else if strings.Contains(strings.ToLower(userAgent), agent) || strings.Contains(agent, strings.ToLower(userAgent)) {
+				current = specific
+				matchedSpecific = true
+			} else {
+				current = nil
+			}
+		case "disallow":
+			if current != nil && value != "" {
+				current.disallow = append(current.disallow, value)
+			}
+		case "crawl-delay":
+			if current != nil {
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+					current.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		case "sitemap":
+			sitemaps = append(sitemaps, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading robots.txt: %v", err)
+	}
+
+	rules := wildcard
+	if matchedSpecific {
+		rules = specific
+	}
+	rules.sitemaps = sitemaps
+	return rules, nil
+}
+
+func splitDirective(line string) (key, value string, ok bool) {
+	idx := strings.Index(line, ":")
+	if idx < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+1:]), true
+}
@@ -0,0 +1,69 @@
+package politeness
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// urlset is a plain sitemap.xml listing pages.
+type urlset struct {
+	XMLName xml.Name `xml:"urlset"`
+	URLs    []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+}
+
+// sitemapIndex is a sitemap index listing further sitemaps.
+type sitemapIndex struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// FetchSitemapURLs downloads sitemapURL and returns every page URL it
+// lists, recursing into any nested sitemap indexes.
+func FetchSitemapURLs(sitemapURL string) ([]string, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	return fetchSitemapURLs(client, sitemapURL)
+}
+
+func fetchSitemapURLs(client *http.Client, sitemapURL string) ([]string, error) {
+	resp, err := client.Get(sitemapURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching sitemap: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading sitemap: %v", err)
+	}
+
+	var set urlset
+	if err := xml.Unmarshal(body, &set); err == nil && len(set.URLs) > 0 {
+		urls := make([]string, 0, len(set.URLs))
+		for _, u := range set.URLs {
+			urls = append(urls, u.Loc)
+		}
+		return urls, nil
+	}
+
+	var index sitemapIndex
+	if err := xml.Unmarshal(body, &index); err != nil {
+		return nil, fmt.Errorf("error parsing sitemap: %v", err)
+	}
+
+	var urls []string
+	for _, sitemap := range index.Sitemaps {
+		nested, err := fetchSitemapURLs(client, sitemap.Loc)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, nested...)
+	}
+	return urls, nil
+}
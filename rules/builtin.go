@@ -0,0 +1,14 @@
+package rules
+
+// BuiltinRules returns the default recon rule set: emails, external
+// links, JS source URLs, phone numbers, and cryptocurrency addresses.
+func BuiltinRules() []Rule {
+	return []Rule{
+		{Name: "emails", Type: "regex", Pattern: `[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`},
+		{Name: "external_links", Type: "external_links", Pattern: `a[href^="http"]`},
+		{Name: "js_sources", Type: "regex", Pattern: `https?://[^\s"'<>]+\.js\b`},
+		{Name: "phone_numbers", Type: "regex", Pattern: `\+?\d[\d\-. ()]{7,}\d`},
+		{Name: "bitcoin_addresses", Type: "regex", Pattern: `\b[13][a-km-zA-HJ-NP-Z1-9]{25,34}\b`},
+		{Name: "ethereum_addresses", Type: "regex", Pattern: `\b0x[a-fA-F0-9]{40}\b`},
+	}
+}
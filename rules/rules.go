@@ -0,0 +1,238 @@
+// Package rules implements a post-extraction "scraper rules" layer,
+// modeled on ffuf's -scrapers: after a page's HTML has been extracted
+// into DataRecords, a set of regex/CSS rules runs over the raw page body
+// to pull out secondary artifacts (emails, links, phone numbers, ...)
+// that aren't part of the structured record schema.
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Rule describes a single post-extraction scraper, e.g.
+//
+//	{"name": "emails", "type": "regex", "pattern": "[\\w.+-]+@[\\w-]+\\.[a-zA-Z]{2,}"}
+type Rule struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"` // "regex", "css", or "external_links"
+	Pattern    string `json:"pattern"`
+	OutputFile string `json:"output_file,omitempty"`
+
+	compiled *regexp.Regexp
+}
+
+// UnmarshalJSON accepts "output-file" as an alias for "output_file"; when
+// both are present, "output_file" wins.
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	type rawRule Rule
+	aux := struct {
+		OutputFileAlias string `json:"output-file,omitempty"`
+		*rawRule
+	}{rawRule: (*rawRule)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if r.OutputFile == "" && aux.OutputFileAlias != "" {
+		r.OutputFile = aux.OutputFileAlias
+	}
+	return nil
+}
+
+// LoadDir reads every *.json file in dir as a single Rule.
+func LoadDir(dir string) ([]Rule, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rules directory: %v", err)
+	}
+
+	var loaded []Rule
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading rule %s: %v", entry.Name(), err)
+		}
+		var rule Rule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			return nil, fmt.Errorf("error parsing rule %s: %v", entry.Name(), err)
+		}
+		loaded = append(loaded, rule)
+	}
+	return loaded, nil
+}
+
+// Engine runs a fixed set of Rules against each page and streams matches
+// to any configured per-rule sidecar files.
+type Engine struct {
+	rules    []Rule
+	mu       sync.Mutex
+	sidecars map[string]*os.File
+}
+
+// NewEngine compiles rules and opens any sidecar files they declare.
+func NewEngine(ruleSet []Rule) (*Engine, error) {
+	e := &Engine{sidecars: make(map[string]*os.File)}
+
+	for i := range ruleSet {
+		rule := ruleSet[i]
+		if rule.Type == "regex" {
+			compiled, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("error compiling rule %q: %v", rule.Name, err)
+			}
+			rule.compiled = compiled
+		}
+
+		if rule.OutputFile != "" {
+			if _, ok := e.sidecars[rule.OutputFile]; !ok {
+				file, err := os.OpenFile(rule.OutputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+				if err != nil {
+					return nil, fmt.Errorf("error opening sidecar file for rule %q: %v", rule.Name, err)
+				}
+				e.sidecars[rule.OutputFile] = file
+			}
+		}
+
+		e.rules = append(e.rules, rule)
+	}
+
+	return e, nil
+}
+
+// Run applies every rule to a single page and returns each rule's unique
+// matches keyed by rule name. Matches are also appended to that rule's
+// sidecar file, if configured.
+func (e *Engine) Run(pageURL, html string, doc *goquery.Document) (map[string][]string, error) {
+	results := make(map[string][]string)
+
+	for _, rule := range e.rules {
+		var matches []string
+		switch rule.Type {
+		case "regex":
+			matches = dedup(rule.compiled.FindAllString(html, -1))
+		case "css":
+			matches = dedup(cssMatches(doc, rule.Pattern))
+		case "external_links":
+			matches = dedup(externalLinkMatches(doc, rule.Pattern, pageURL))
+		default:
+			return nil, fmt.Errorf("unknown scraper rule type %q for rule %q", rule.Type, rule.Name)
+		}
+
+		if len(matches) == 0 {
+			continue
+		}
+		results[rule.Name] = matches
+
+		if rule.OutputFile != "" {
+			if err := e.writeSidecar(rule.OutputFile, pageURL, matches); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func (e *Engine) writeSidecar(path, pageURL string, matches []string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	file := e.sidecars[path]
+	for _, match := range matches {
+		if _, err := fmt.Fprintf(file, "%s\t%s\n", pageURL, match); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes and closes every sidecar file.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var firstErr error
+	for _, file := range e.sidecars {
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// cssMatches returns, for every element matching selector, its href or
+// src attribute if present, otherwise its trimmed text.
+func cssMatches(doc *goquery.Document, selector string) []string {
+	var matches []string
+	doc.Find(selector).Each(func(_ int, sel *goquery.Selection) {
+		if href, ok := sel.Attr("href"); ok && href != "" {
+			matches = append(matches, href)
+			return
+		}
+		if src, ok := sel.Attr("src"); ok && src != "" {
+			matches = append(matches, src)
+			return
+		}
+		if text := strings.TrimSpace(sel.Text()); text != "" {
+			matches = append(matches, text)
+		}
+	})
+	return matches
+}
+
+// externalLinkMatches is like cssMatches but keeps only hrefs that resolve
+// to a different host than pageURL, which is what the "external_links"
+// rule type is for. Plain "css" rules (e.g. a custom rule harvesting
+// same-site links) go through cssMatches instead and see every match.
+func externalLinkMatches(doc *goquery.Document, selector, pageURL string) []string {
+	pageHost := hostOf(pageURL)
+
+	var matches []string
+	doc.Find(selector).Each(func(_ int, sel *goquery.Selection) {
+		href, ok := sel.Attr("href")
+		if !ok || href == "" {
+			return
+		}
+		// A relative href (no host of its own) is always same-site.
+		if linkHost := hostOf(href); linkHost == "" || linkHost == pageHost {
+			return
+		}
+		matches = append(matches, href)
+	})
+	return matches
+}
+
+// hostOf returns the host component of rawURL, or "" if it can't be
+// parsed or has no host (e.g. a relative path).
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+func dedup(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	var unique []string
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		unique = append(unique, v)
+	}
+	return unique
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// OutputSink receives scraped records and is responsible for persisting
+// them in whatever format it implements. newOutputSink picks one based on
+// config.OutputFormat.
+type OutputSink interface {
+	WriteRecord(record DataRecord) error
+	Close() error
+}
+
+// PageArchiver is an optional capability: sinks that also want the raw
+// HTML of every fetched page (currently only the WARC sink) implement it,
+// and fetchPage calls it after each successful navigation.
+type PageArchiver interface {
+	WritePage(pageURL string, html []byte, fetchedAt time.Time) error
+}
+
+// newOutputSink builds the OutputSink for config.OutputFormat, rooted at
+// config.OutputFile plus the format's conventional extension.
+func newOutputSink(config ScraperConfig) (OutputSink, error) {
+	switch strings.ToLower(config.OutputFormat) {
+	case "json":
+		return newJSONSink(config.OutputFile + ".json")
+	case "csv":
+		return newCSVSink(config.OutputFile + ".csv")
+	case "jsonl":
+		return newJSONLSink(config.OutputFile + ".jsonl")
+	case "sqlite":
+		return newSQLiteSink(config.OutputFile+".db", "records")
+	case "warc":
+		return newWARCSink(config.OutputFile + ".warc")
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", config.OutputFormat)
+	}
+}
+
+// jsonSink buffers every record and writes them as a single JSON array on
+// Close, matching the tool's original JSON output.
+type jsonSink struct {
+	fileName string
+	mu       sync.Mutex
+	records  []DataRecord
+}
+
+func newJSONSink(fileName string) (*jsonSink, error) {
+	return &jsonSink{fileName: fileName}, nil
+}
+
+func (s *jsonSink) WriteRecord(record DataRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *jsonSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fixupRecordURLs(s.records)
+
+	file, err := os.Create(s.fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(s.records)
+}
+
+// jsonlSink writes one JSON object per line as records arrive, flushing
+// immediately instead of buffering the whole crawl in memory.
+type jsonlSink struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func newJSONLSink(fileName string) (*jsonlSink, error) {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return nil, err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+	return &jsonlSink{file: file, encoder: encoder}, nil
+}
+
+func (s *jsonlSink) WriteRecord(record DataRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.encoder.Encode(record); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *jsonlSink) Close() error {
+	return s.file.Close()
+}
+
+// csvSink streams rows to disk, writing the header from the first record
+// it sees.
+type csvSink struct {
+	mu      sync.Mutex
+	file    *os.File
+	writer  *csv.Writer
+	headers []string
+}
+
+func newCSVSink(fileName string) (*csvSink, error) {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return &csvSink{file: file, writer: csv.NewWriter(file)}, nil
+}
+
+func (s *csvSink) WriteRecord(record DataRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.headers == nil {
+		for key := range record {
+			s.headers = append(s.headers, key)
+		}
+		sort.Strings(s.headers)
+		if err := s.writer.Write(s.headers); err != nil {
+			return err
+		}
+	}
+
+	row := make([]string, len(s.headers))
+	for i, header := range s.headers {
+		row[i] = record[header]
+	}
+	if err := s.writer.Write(row); err != nil {
+		return err
+	}
+	s.writer.Flush()
+	return s.writer.Error()
+}
+
+func (s *csvSink) Close() error {
+	s.writer.Flush()
+	return s.file.Close()
+}
+
+// sqliteSink writes each record as a row in a single table, one column per
+// field. The table is created lazily from the first record's keys and
+// grown with ALTER TABLE ADD COLUMN as later records introduce new keys
+// (e.g. a "matches" column that only shows up on pages where the scraper
+// rules found something), so no field is ever silently dropped.
+type sqliteSink struct {
+	mu        sync.Mutex
+	db        *sql.DB
+	table     string
+	columns   []string
+	columnSet map[string]struct{}
+	insertSQL string
+}
+
+func newSQLiteSink(fileName, table string) (*sqliteSink, error) {
+	db, err := sql.Open("sqlite", fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error opening sqlite db: %v", err)
+	}
+	return &sqliteSink{db: db, table: table}, nil
+}
+
+func (s *sqliteSink) WriteRecord(record DataRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.columns == nil {
+		if err := s.createTable(record); err != nil {
+			return err
+		}
+	} else if err := s.addMissingColumns(record); err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(s.columns))
+	for i, column := range s.columns {
+		values[i] = record[column]
+	}
+	_, err := s.db.Exec(s.insertSQL, values...)
+	return err
+}
+
+func (s *sqliteSink) createTable(record DataRecord) error {
+	var columns []string
+	for key := range record {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+
+	var colDefs []string
+	for _, column := range columns {
+		colDefs = append(colDefs, fmt.Sprintf("%q TEXT", column))
+	}
+	createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %q (%s)", s.table, strings.Join(colDefs, ", "))
+	if _, err := s.db.Exec(createSQL); err != nil {
+		return fmt.Errorf("error creating sqlite table: %v", err)
+	}
+
+	s.columns = columns
+	s.columnSet = make(map[string]struct{}, len(columns))
+	for _, column := range columns {
+		s.columnSet[column] = struct{}{}
+	}
+	s.rebuildInsertSQL()
+	return nil
+}
+
+// addMissingColumns widens the table with an ALTER TABLE ADD COLUMN for any
+// key in record that earlier records didn't have, so later-appearing
+// fields (e.g. a per-page "matches" column) aren't dropped on the floor.
+func (s *sqliteSink) addMissingColumns(record DataRecord) error {
+	var added []string
+	for key := range record {
+		if _, ok := s.columnSet[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	sort.Strings(added)
+
+	for _, column := range added {
+		alterSQL := fmt.Sprintf("ALTER TABLE %q ADD COLUMN %q TEXT", s.table, column)
+		if _, err := s.db.Exec(alterSQL); err != nil {
+			return fmt.Errorf("error adding sqlite column %q: %v", column, err)
+		}
+		s.columnSet[column] = struct{}{}
+	}
+	s.columns = append(s.columns, added...)
+	s.rebuildInsertSQL()
+	return nil
+}
+
+func (s *sqliteSink) rebuildInsertSQL() {
+	placeholders := make([]string, len(s.columns))
+	for i := range s.columns {
+		placeholders[i] = "?"
+	}
+	s.insertSQL = fmt.Sprintf("INSERT INTO %q (%s) VALUES (%s)", s.table,
+		strings.Join(quoteAll(s.columns), ", "), strings.Join(placeholders, ", "))
+}
+
+func quoteAll(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return quoted
+}
+
+func (s *sqliteSink) Close() error {
+	return s.db.Close()
+}
+
+// fixupRecordURLs repairs the Coupang-specific URL-escaping glitches the
+// original saveAsJSON worked around. Only the JSON sink applies it; the
+// CSV, JSONL and SQLite sinks write URLs as extracted.
+func fixupRecordURLs(records []DataRecord) {
+	for i := range records {
+		if url, ok := records[i]["product_url"]; ok && url != "" {
+			url = strings.Replace(url, "//vp", "/vp", -1)
+			url = strings.ReplaceAll(url, "\\u0026", "&")
+			url = strings.ReplaceAll(url, "&", "&")
+			records[i]["product_url"] = url
+		}
+
+		if url, ok := records[i]["image_url"]; ok && url != "" {
+			url = strings.Replace(url, "///thumbnail", "//thumbnail", -1)
+			url = strings.Replace(url, "//thumbnail", "/thumbnail", -1)
+			if strings.HasPrefix(url, "https:/") && !strings.HasPrefix(url, "https://") {
+				url = strings.Replace(url, "https:/", "https://", 1)
+			}
+			records[i]["image_url"] = url
+		}
+	}
+}
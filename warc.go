@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// warcSink archives the raw HTML of every fetched page as WARC 1.1
+// request/response record pairs, so the crawl can be replayed in tools
+// like pywb/OpenWayback or re-extracted later with different selectors.
+// It implements PageArchiver rather than anything useful for WriteRecord,
+// since a WARC file cares about the page, not the extracted DataRecord.
+type warcSink struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+}
+
+func newWARCSink(fileName string) (*warcSink, error) {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &warcSink{file: file, w: bufio.NewWriter(file)}
+	if err := s.writeWarcinfo(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// WriteRecord is a no-op: WARC archives raw pages, not extracted records.
+func (s *warcSink) WriteRecord(DataRecord) error { return nil }
+
+// WritePage appends a request/response record pair for a single fetched
+// page.
+func (s *warcSink) WritePage(pageURL string, html []byte, fetchedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recordID := warcRecordID()
+	date := fetchedAt.UTC().Format("2006-01-02T15:04:05Z")
+
+	requestPayload := fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\n\r\n", pageURL, hostOf(pageURL))
+	if err := s.writeRecord(map[string]string{
+		"WARC-Type":       "request",
+		"WARC-Record-ID":  recordID,
+		"WARC-Target-URI": pageURL,
+		"WARC-Date":       date,
+		"Content-Type":    "application/http; msgtype=request",
+	}, []byte(requestPayload)); err != nil {
+		return err
+	}
+
+	responsePayload := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\nContent-Length: %d\r\n\r\n", len(html))
+	responseRecord := append([]byte(responsePayload), html...)
+	if err := s.writeRecord(map[string]string{
+		"WARC-Type":           "response",
+		"WARC-Record-ID":      warcRecordID(),
+		"WARC-Concurrent-To":  recordID,
+		"WARC-Target-URI":     pageURL,
+		"WARC-Date":           date,
+		"WARC-Payload-Digest": "sha1:" + sha1Digest(html),
+		"Content-Type":        "application/http; msgtype=response",
+	}, responseRecord); err != nil {
+		return err
+	}
+
+	return s.w.Flush()
+}
+
+func (s *warcSink) writeWarcinfo() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body := []byte("software: crawler\r\nformat: WARC File Format 1.1\r\n")
+	if err := s.writeRecord(map[string]string{
+		"WARC-Type":      "warcinfo",
+		"WARC-Record-ID": warcRecordID(),
+		"WARC-Date":      time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		"Content-Type":   "application/warc-fields",
+	}, body); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// writeRecord emits a single WARC 1.1 record with the given headers (in
+// addition to Content-Length, which is always set) and body. Callers must
+// supply WARC-Record-ID in headers and must hold s.mu.
+func (s *warcSink) writeRecord(headers map[string]string, body []byte) error {
+	if _, err := fmt.Fprintf(s.w, "WARC/1.1\r\n"); err != nil {
+		return err
+	}
+	for key, value := range headers {
+		if _, err := fmt.Fprintf(s.w, "%s: %s\r\n", key, value); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(body); err != nil {
+		return err
+	}
+	_, err := s.w.WriteString("\r\n\r\n")
+	return err
+}
+
+func (s *warcSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return s.file.Close()
+}
+
+func warcRecordID() string {
+	return "<urn:uuid:" + uuid.NewString() + ">"
+}
+
+func sha1Digest(data []byte) string {
+	sum := sha1.Sum(data)
+	return base32.StdEncoding.EncodeToString(sum[:])
+}